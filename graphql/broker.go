@@ -0,0 +1,87 @@
+package graphql
+
+import "sync"
+
+// broker is a small in-memory pub/sub fan-out used to bridge Nakama's
+// internal match, notification, and presence streams onto GraphQL
+// subscriptions. Server-side code that already observes those streams
+// (match handlers, the notification dispatcher, presence hooks) should
+// call the Publish* helpers below as events occur; subscriptionFields'
+// Subscribe functions call Subscribe to receive them.
+type broker struct {
+	mu   sync.Mutex
+	subs map[string]map[chan interface{}]struct{}
+}
+
+func newBroker() *broker {
+	return &broker{subs: make(map[string]map[chan interface{}]struct{})}
+}
+
+// Subscribe returns a channel fed every value Publish(topic, ...) sends,
+// and a cancel func the caller must invoke to stop receiving and release
+// the channel.
+func (b *broker) Subscribe(topic string) (<-chan interface{}, func()) {
+	ch := make(chan interface{}, 16)
+
+	b.mu.Lock()
+	if b.subs[topic] == nil {
+		b.subs[topic] = make(map[chan interface{}]struct{})
+	}
+	b.subs[topic][ch] = struct{}{}
+	b.mu.Unlock()
+
+	cancel := func() {
+		b.mu.Lock()
+		delete(b.subs[topic], ch)
+		if len(b.subs[topic]) == 0 {
+			delete(b.subs, topic)
+		}
+		b.mu.Unlock()
+		close(ch)
+	}
+	return ch, cancel
+}
+
+// Publish fans value out to every current subscriber of topic. Subscribers
+// that aren't keeping up are skipped rather than blocking the publisher.
+func (b *broker) Publish(topic string, value interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs[topic] {
+		select {
+		case ch <- value:
+		default:
+		}
+	}
+}
+
+var eventBroker = newBroker()
+
+func matchStateTopic(matchID string) string        { return "match:" + matchID }
+func notificationsTopic(userID string) string      { return "notifications:" + userID }
+func channelMessagesTopic(channelID string) string { return "channel:" + channelID }
+func presenceEventsTopic(stream string) string     { return "presence:" + stream }
+
+// PublishMatchState notifies any matchState(matchId) subscribers of a new
+// state snapshot for matchID.
+func PublishMatchState(matchID string, state interface{}) {
+	eventBroker.Publish(matchStateTopic(matchID), state)
+}
+
+// PublishNotification notifies any notifications(userId) subscribers for
+// the recipient user.
+func PublishNotification(notification interface{}, userID string) {
+	eventBroker.Publish(notificationsTopic(userID), notification)
+}
+
+// PublishChannelMessage notifies any channelMessages(channelId) subscribers
+// of a new message on channelID.
+func PublishChannelMessage(channelID string, message interface{}) {
+	eventBroker.Publish(channelMessagesTopic(channelID), message)
+}
+
+// PublishPresenceEvent notifies any presenceEvents(stream) subscribers of a
+// join/leave event on the given stream.
+func PublishPresenceEvent(stream string, event interface{}) {
+	eventBroker.Publish(presenceEventsTopic(stream), event)
+}
@@ -0,0 +1,160 @@
+package graphql
+
+import "github.com/graphql-go/graphql"
+
+// SchemaBuilder assembles a graphql.Schema from contributions made by the
+// built-in Nakama types plus any number of game-specific modules living in
+// the same binary. Before this, schema was a package-level singleton built
+// from a fixed fields map, so a downstream module (inventories, quests,
+// matchmaking tickets, ...) had no way to add its own queries or mutations
+// without editing this package directly.
+type SchemaBuilder struct {
+	queries       graphql.Fields
+	mutations     graphql.Fields
+	subscriptions graphql.Fields
+	types         []graphql.Type
+	directives    []*graphql.Directive
+}
+
+// NewSchemaBuilder returns an empty builder. Most callers want
+// NewBaseSchemaBuilder instead, which preloads Poseidon's own Nakama types.
+func NewSchemaBuilder() *SchemaBuilder {
+	return &SchemaBuilder{
+		queries:       graphql.Fields{},
+		mutations:     graphql.Fields{},
+		subscriptions: graphql.Fields{},
+	}
+}
+
+// NewBaseSchemaBuilder returns a builder preloaded with the queries,
+// mutations, and subscriptions this package defines over the core Nakama
+// domain (users, accounts, groups, storage, leaderboards, matches).
+func NewBaseSchemaBuilder() *SchemaBuilder {
+	b := NewSchemaBuilder()
+	for name, field := range queryFields {
+		b.AddQuery(name, field)
+	}
+	for name, field := range mutationFields {
+		b.AddMutation(name, field)
+	}
+	for name, field := range subscriptionFields {
+		b.AddSubscription(name, field)
+	}
+	return b
+}
+
+// AddQuery registers a top-level query field. It panics if name is already
+// registered, since two modules silently shadowing each other's query is
+// almost certainly a configuration mistake best caught at startup.
+func (b *SchemaBuilder) AddQuery(name string, field *graphql.Field) *SchemaBuilder {
+	mustBeUnique(b.queries, name, "query")
+	b.queries[name] = field
+	return b
+}
+
+// AddMutation registers a top-level mutation field.
+func (b *SchemaBuilder) AddMutation(name string, field *graphql.Field) *SchemaBuilder {
+	mustBeUnique(b.mutations, name, "mutation")
+	b.mutations[name] = field
+	return b
+}
+
+// AddSubscription registers a top-level subscription field.
+func (b *SchemaBuilder) AddSubscription(name string, field *graphql.Field) *SchemaBuilder {
+	mustBeUnique(b.subscriptions, name, "subscription")
+	b.subscriptions[name] = field
+	return b
+}
+
+// AddType registers an additional object/interface/union/enum/input type
+// that isn't necessarily reachable from a query or mutation field's return
+// type alone, e.g. an interface implementation graphql-go needs to know
+// about up front.
+func (b *SchemaBuilder) AddType(t graphql.Type) *SchemaBuilder {
+	b.types = append(b.types, t)
+	return b
+}
+
+// AddDirective registers a custom schema directive, e.g. an @auth or
+// @deprecated(reason:) directive a game module wants to apply to its own
+// fields.
+func (b *SchemaBuilder) AddDirective(d *graphql.Directive) *SchemaBuilder {
+	b.directives = append(b.directives, d)
+	return b
+}
+
+func mustBeUnique(fields graphql.Fields, name, kind string) {
+	if _, exists := fields[name]; exists {
+		panic("graphql: duplicate " + kind + " field \"" + name + "\" registered with SchemaBuilder")
+	}
+}
+
+// Build finalizes the schema. Subscription is only attached when the
+// builder holds at least one subscription field, since graphql-go
+// rejects a Subscription root object with no fields.
+func (b *SchemaBuilder) Build() (graphql.Schema, error) {
+	config := graphql.SchemaConfig{
+		Query:      graphql.NewObject(graphql.ObjectConfig{Name: "RootQuery", Fields: b.queries}),
+		Mutation:   graphql.NewObject(graphql.ObjectConfig{Name: "RootMutation", Fields: b.mutations}),
+		Types:      b.types,
+		Directives: b.directives,
+	}
+	if len(b.subscriptions) > 0 {
+		config.Subscription = graphql.NewObject(graphql.ObjectConfig{Name: "RootSubscription", Fields: b.subscriptions})
+	}
+	return graphql.NewSchema(config)
+}
+
+// MergeSchemas combines any number of builders into one, for stitching a
+// base builder together with one or more game-specific SchemaExtensions
+// built independently. It fails on the first colliding query, mutation, or
+// subscription field name rather than silently letting one shadow another.
+func MergeSchemas(builders ...*SchemaBuilder) (*SchemaBuilder, error) {
+	merged := NewSchemaBuilder()
+	var err error
+	for _, b := range builders {
+		for name, field := range b.queries {
+			if err = addUnique(merged.queries, name, field, "query"); err != nil {
+				return nil, err
+			}
+		}
+		for name, field := range b.mutations {
+			if err = addUnique(merged.mutations, name, field, "mutation"); err != nil {
+				return nil, err
+			}
+		}
+		for name, field := range b.subscriptions {
+			if err = addUnique(merged.subscriptions, name, field, "subscription"); err != nil {
+				return nil, err
+			}
+		}
+		merged.types = append(merged.types, b.types...)
+		merged.directives = append(merged.directives, b.directives...)
+	}
+	return merged, nil
+}
+
+func addUnique(fields graphql.Fields, name string, field *graphql.Field, kind string) error {
+	if _, exists := fields[name]; exists {
+		return duplicateFieldError(name, kind)
+	}
+	fields[name] = field
+	return nil
+}
+
+func duplicateFieldError(name, kind string) error {
+	return &duplicateFieldErr{name: name, kind: kind}
+}
+
+type duplicateFieldErr struct {
+	name, kind string
+}
+
+func (e *duplicateFieldErr) Error() string {
+	return "graphql: duplicate " + e.kind + " field \"" + e.name + "\" while merging schemas"
+}
+
+// SchemaExtension lets a game-specific module contribute queries,
+// mutations, subscriptions, types, and directives to the schema
+// RegisterGraphQL builds, without needing to edit this package.
+type SchemaExtension func(b *SchemaBuilder)
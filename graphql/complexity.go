@@ -0,0 +1,176 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// Budget caps how expensive a single incoming document is allowed to be,
+// so a malicious or buggy client can't turn the single-field stub this
+// schema used to be into an arbitrarily deep or expensive query against
+// Nakama through the RPC surface.
+type Budget struct {
+	MaxDepth      int
+	MaxNodes      int
+	MaxComplexity int
+}
+
+// DefaultBudget is applied to every request unless a caller overrides it
+// with SetBudget before RegisterGraphQL.
+var DefaultBudget = Budget{
+	MaxDepth:      12,
+	MaxNodes:      500,
+	MaxComplexity: 1000,
+}
+
+var budget = DefaultBudget
+
+// SetBudget overrides the complexity budget enforced before graphql.Do runs.
+func SetBudget(b Budget) {
+	budget = b
+}
+
+// fieldCosts gives a handful of fields a cost proportional to the result
+// set they can return, e.g. leaderboardRecords(limit: N) costs N instead of
+// the default 1, since each extra record is another row Nakama has to read
+// and marshal.
+var fieldCosts = map[string]string{
+	"leaderboardRecords": "limit",
+	"matches":            "limit",
+	"members":            "limit",
+}
+
+// checkComplexity parses requestString and rejects it before execution if
+// it exceeds the configured Budget. variables is the request's own
+// VariableValues, so a cost-bearing argument passed as `limit: $n` is
+// weighed by the actual value of $n instead of the default of 1 - otherwise
+// a client could dodge the budget entirely just by moving "limit" into a
+// variable. It also returns the parsed document.
+func checkComplexity(requestString string, variables map[string]interface{}) (*ast.Document, error) {
+	doc, err := parser.Parse(parser.ParseParams{Source: requestString})
+	if err != nil {
+		return nil, err
+	}
+
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, definition := range doc.Definitions {
+		if frag, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[frag.Name.Value] = frag
+		}
+	}
+
+	nodes := 0
+	maxDepth := 0
+	complexity := 0
+
+	// visiting guards against a fragment (directly or transitively) spreading
+	// itself, which would otherwise recurse forever.
+	visiting := map[string]bool{}
+
+	var walk func(selectionSet *ast.SelectionSet, depth int, multiplier int)
+	walk = func(selectionSet *ast.SelectionSet, depth int, multiplier int) {
+		if selectionSet == nil {
+			return
+		}
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		for _, selection := range selectionSet.Selections {
+			switch sel := selection.(type) {
+			case *ast.Field:
+				nodes++
+				cost := multiplier
+				if argName, ok := fieldCosts[sel.Name.Value]; ok {
+					cost *= limitArgValue(sel, argName, variables)
+				}
+				complexity += cost
+				walk(sel.SelectionSet, depth+1, cost)
+			case *ast.InlineFragment:
+				// A type condition narrows what the selection applies to at
+				// runtime, not how deep or expensive it is, so it's weighed
+				// at the same depth and multiplier as its surrounding set.
+				walk(sel.SelectionSet, depth, multiplier)
+			case *ast.FragmentSpread:
+				name := sel.Name.Value
+				if visiting[name] {
+					continue
+				}
+				frag, ok := fragments[name]
+				if !ok {
+					continue
+				}
+				visiting[name] = true
+				walk(frag.SelectionSet, depth, multiplier)
+				visiting[name] = false
+			}
+		}
+	}
+
+	for _, definition := range doc.Definitions {
+		op, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		walk(op.SelectionSet, 1, 1)
+	}
+
+	switch {
+	case maxDepth > budget.MaxDepth:
+		return nil, fmt.Errorf("query exceeds max depth of %d", budget.MaxDepth)
+	case nodes > budget.MaxNodes:
+		return nil, fmt.Errorf("query exceeds max node count of %d", budget.MaxNodes)
+	case complexity > budget.MaxComplexity:
+		return nil, fmt.Errorf("query exceeds max complexity of %d", budget.MaxComplexity)
+	}
+	return doc, nil
+}
+
+// limitArgValue reads an integer argument off a field, resolving it against
+// variables when it's supplied as `arg: $name` rather than a literal, and
+// defaulting to 1 only when the argument is absent or can't be resolved to
+// a usable number.
+func limitArgValue(field *ast.Field, argName string, variables map[string]interface{}) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != argName {
+			continue
+		}
+		switch value := arg.Value.(type) {
+		case *ast.IntValue:
+			parsed := 0
+			fmt.Sscanf(value.Value, "%d", &parsed)
+			if parsed > 0 {
+				return parsed
+			}
+		case *ast.Variable:
+			if raw, ok := variables[value.Name.Value]; ok {
+				if parsed, ok := toPositiveInt(raw); ok {
+					return parsed
+				}
+			}
+		}
+	}
+	return 1
+}
+
+// toPositiveInt coerces a decoded JSON variable value to a positive int,
+// covering the numeric types encoding/json can produce for a request body.
+func toPositiveInt(raw interface{}) (int, bool) {
+	switch v := raw.(type) {
+	case float64:
+		if v > 0 {
+			return int(v), true
+		}
+	case int:
+		if v > 0 {
+			return v, true
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil && n > 0 {
+			return int(n), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,63 @@
+package graphql
+
+import "testing"
+
+func TestCheckComplexityLiteralLimitCost(t *testing.T) {
+	query := `{ leaderboardRecords(leaderboardId: "lb", limit: 50) { score } }`
+	if _, err := checkComplexity(query, nil); err != nil {
+		t.Fatalf("expected query within budget to pass, got %v", err)
+	}
+
+	budget = Budget{MaxDepth: 12, MaxNodes: 500, MaxComplexity: 10}
+	defer func() { budget = DefaultBudget }()
+	if _, err := checkComplexity(query, nil); err == nil {
+		t.Fatal("expected literal limit: 50 to exceed a complexity budget of 10")
+	}
+}
+
+func TestCheckComplexityVariableBoundLimitCost(t *testing.T) {
+	query := `query($n: Int) { leaderboardRecords(leaderboardId: "lb", limit: $n) { score } }`
+
+	budget = Budget{MaxDepth: 12, MaxNodes: 500, MaxComplexity: 10}
+	defer func() { budget = DefaultBudget }()
+
+	if _, err := checkComplexity(query, map[string]interface{}{"n": float64(5)}); err != nil {
+		t.Fatalf("expected limit bound to a small variable to pass, got %v", err)
+	}
+	if _, err := checkComplexity(query, map[string]interface{}{"n": float64(1000)}); err == nil {
+		t.Fatal("expected a variable-bound limit to be weighed against the budget, not treated as cost 1")
+	}
+}
+
+func TestCheckComplexityResolvesFragmentSpreads(t *testing.T) {
+	budget = Budget{MaxDepth: 2, MaxNodes: 500, MaxComplexity: 1000}
+	defer func() { budget = DefaultBudget }()
+
+	query := `
+		{ group(id: "g") { ...deep } }
+		fragment deep on Group { members(limit: 1) { user { id } } }
+	`
+	if _, err := checkComplexity(query, nil); err == nil {
+		t.Fatal("expected depth inside a fragment spread to count toward MaxDepth")
+	}
+}
+
+func TestCheckComplexityResolvesInlineFragments(t *testing.T) {
+	budget = Budget{MaxDepth: 2, MaxNodes: 500, MaxComplexity: 1000}
+	defer func() { budget = DefaultBudget }()
+
+	query := `{ group(id: "g") { ... on Group { members(limit: 1) { user { id } } } } }`
+	if _, err := checkComplexity(query, nil); err == nil {
+		t.Fatal("expected depth inside an inline fragment to count toward MaxDepth")
+	}
+}
+
+func TestCheckComplexityRejectsExcessiveDepth(t *testing.T) {
+	budget = Budget{MaxDepth: 2, MaxNodes: 500, MaxComplexity: 1000}
+	defer func() { budget = DefaultBudget }()
+
+	query := `{ group(id: "g") { members(limit: 1) { user { id } } } }`
+	if _, err := checkComplexity(query, nil); err == nil {
+		t.Fatal("expected a query nested past MaxDepth to be rejected")
+	}
+}
@@ -0,0 +1,148 @@
+// Package dataloader provides a per-request batching and caching loader
+// modeled on gqlgen's dataloader integration, so nested GraphQL selections
+// such as group { members { user { ... } } } issue one Nakama RPC per batch
+// of distinct keys instead of one per resolver invocation.
+package dataloader
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchFunc fetches values for a batch of keys. It must return exactly one
+// result per key, in the same order, so the loader can demultiplex errors
+// back to individual callers.
+type BatchFunc func(ctx context.Context, keys []string) []Result
+
+// Result is the outcome of resolving a single key.
+type Result struct {
+	Value interface{}
+	Error error
+}
+
+// wait is how long the loader holds a batch open for more callers before
+// flushing it. It's short enough not to add perceptible latency to a single
+// request but long enough to coalesce the resolver calls a nested query
+// issues within the same tick of the event loop.
+const wait = time.Millisecond
+
+// maxBatch caps how many keys are sent to BatchFunc at once; 0 means no
+// per-batch limit, only the tick above bounds it.
+const maxBatch = 0
+
+// Loader batches and caches calls to a single BatchFunc for the lifetime of
+// one request. It is not safe to share across requests: the cache would
+// grow unboundedly and would eventually serve stale data.
+type Loader struct {
+	fetch BatchFunc
+
+	mu    sync.Mutex
+	cache map[string]*thunk
+	batch *batch
+}
+
+type thunk struct {
+	once  sync.Once
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+func (t *thunk) resolve(value interface{}, err error) {
+	t.once.Do(func() {
+		t.value = value
+		t.err = err
+		close(t.done)
+	})
+}
+
+func (t *thunk) wait() (interface{}, error) {
+	<-t.done
+	return t.value, t.err
+}
+
+type batch struct {
+	ctx     context.Context
+	keys    []string
+	thunks  []*thunk
+	closing bool
+	done    chan struct{}
+}
+
+// New constructs a Loader around a BatchFunc. Construct one per request,
+// per entity kind - see Loaders for the set Poseidon wires into the
+// GraphQL context.
+func New(fetch BatchFunc) *Loader {
+	return &Loader{
+		fetch: fetch,
+		cache: make(map[string]*thunk),
+	}
+}
+
+// Load fetches a single key, transparently joining an in-flight batch or
+// starting a new one, and returns the cached result for any repeated key
+// within the same request. The ctx of whichever call opens a batch is the
+// one BatchFunc runs with, so the caller's deadline/cancellation reaches
+// Nakama even though later callers joining the same batch may pass a
+// different ctx.
+func (l *Loader) Load(ctx context.Context, key string) (interface{}, error) {
+	l.mu.Lock()
+	if t, ok := l.cache[key]; ok {
+		l.mu.Unlock()
+		return t.wait()
+	}
+
+	t := &thunk{done: make(chan struct{})}
+	l.cache[key] = t
+
+	b := l.batch
+	if b == nil || b.closing || (maxBatch > 0 && len(b.keys) >= maxBatch) {
+		b = &batch{ctx: ctx, done: make(chan struct{})}
+		l.batch = b
+		time.AfterFunc(wait, func() { l.flush(b) })
+	}
+	b.keys = append(b.keys, key)
+	b.thunks = append(b.thunks, t)
+	l.mu.Unlock()
+
+	return t.wait()
+}
+
+// LoadMany fetches several keys concurrently within the same request-scoped
+// cache, returning results in the same order as keys.
+func (l *Loader) LoadMany(ctx context.Context, keys []string) ([]interface{}, []error) {
+	values := make([]interface{}, len(keys))
+	errs := make([]error, len(keys))
+	var wg sync.WaitGroup
+	wg.Add(len(keys))
+	for i, key := range keys {
+		go func(i int, key string) {
+			defer wg.Done()
+			values[i], errs[i] = l.Load(ctx, key)
+		}(i, key)
+	}
+	wg.Wait()
+	return values, errs
+}
+
+func (l *Loader) flush(b *batch) {
+	l.mu.Lock()
+	b.closing = true
+	if l.batch == b {
+		l.batch = nil
+	}
+	keys := b.keys
+	thunks := b.thunks
+	l.mu.Unlock()
+
+	results := l.fetch(b.ctx, keys)
+	for i, t := range thunks {
+		if i < len(results) {
+			t.resolve(results[i].Value, results[i].Error)
+		} else {
+			t.resolve(nil, nil)
+		}
+	}
+	close(b.done)
+}
@@ -0,0 +1,115 @@
+package dataloader
+
+import (
+	"context"
+	"strings"
+
+	"github.com/heroiclabs/nakama/api"
+	"github.com/heroiclabs/nakama/runtime"
+)
+
+// Loaders is the set of request-scoped loaders stashed in the GraphQL
+// context alongside the Nakama module. Resolvers should call these instead
+// of the runtime.NakamaModule directly whenever the same entity might be
+// requested more than once within a single query.
+type Loaders struct {
+	UserByUsername *Loader
+	UserByID       *Loader
+	GroupByID      *Loader
+	StorageObject  *Loader
+}
+
+// StorageKey joins a storage read's (collection, key, userId) tuple into the
+// single string key the generic Loader requires. Callers building a key to
+// pass to Loaders.StorageObject.Load must use this so reads and the cache
+// populated by the batch function agree on key format.
+func StorageKey(collection, key, userID string) string {
+	return strings.Join([]string{collection, key, userID}, "\x1f")
+}
+
+// NewLoaders builds a fresh set of loaders for one request, backed by nk.
+func NewLoaders(nk runtime.NakamaModule) *Loaders {
+	return &Loaders{
+		UserByUsername: New(func(ctx context.Context, usernames []string) []Result {
+			users, err := nk.UsersGetUsername(ctx, usernames)
+			if err != nil {
+				return uniformError(len(usernames), err)
+			}
+			byUsername := make(map[string]*api.User, len(users))
+			for _, u := range users {
+				byUsername[u.GetUsername()] = u
+			}
+			results := make([]Result, len(usernames))
+			for i, username := range usernames {
+				if u, ok := byUsername[username]; ok {
+					results[i] = Result{Value: u}
+				}
+			}
+			return results
+		}),
+		UserByID: New(func(ctx context.Context, ids []string) []Result {
+			users, err := nk.UsersGetId(ctx, ids)
+			if err != nil {
+				return uniformError(len(ids), err)
+			}
+			byID := make(map[string]*api.User, len(users))
+			for _, u := range users {
+				byID[u.GetId()] = u
+			}
+			results := make([]Result, len(ids))
+			for i, id := range ids {
+				if u, ok := byID[id]; ok {
+					results[i] = Result{Value: u}
+				}
+			}
+			return results
+		}),
+		GroupByID: New(func(ctx context.Context, ids []string) []Result {
+			groups, err := nk.GroupsGetId(ctx, ids)
+			if err != nil {
+				return uniformError(len(ids), err)
+			}
+			byID := make(map[string]*api.Group, len(groups))
+			for _, g := range groups {
+				byID[g.GetId()] = g
+			}
+			results := make([]Result, len(ids))
+			for i, id := range ids {
+				if g, ok := byID[id]; ok {
+					results[i] = Result{Value: g}
+				}
+			}
+			return results
+		}),
+		StorageObject: New(func(ctx context.Context, keys []string) []Result {
+			reads := make([]*runtime.StorageRead, len(keys))
+			for i, k := range keys {
+				parts := strings.Split(k, "\x1f")
+				reads[i] = &runtime.StorageRead{Collection: parts[0], Key: parts[1], UserID: parts[2]}
+			}
+			objects, err := nk.StorageRead(ctx, reads)
+			if err != nil {
+				return uniformError(len(keys), err)
+			}
+			byKey := make(map[string]*api.StorageObject, len(objects))
+			for _, o := range objects {
+				byKey[StorageKey(o.GetCollection(), o.GetKey(), o.GetUserId())] = o
+			}
+			results := make([]Result, len(keys))
+			for i, k := range keys {
+				if o, ok := byKey[k]; ok {
+					results[i] = Result{Value: o}
+				}
+			}
+			return results
+		}),
+	}
+}
+
+func uniformError(n int, err error) []Result {
+	results := make([]Result, n)
+	for i := range results {
+		results[i] = Result{Error: err}
+	}
+	return results
+}
@@ -2,13 +2,15 @@ package graphql
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
 
 	"github.com/graphql-go/graphql"
-	"github.com/heroiclabs/nakama/api"
 	"github.com/heroiclabs/nakama/runtime"
 
+	"github.com/mastern2k3/poseidon/graphql/dataloader"
 	"github.com/mastern2k3/poseidon/rpc"
 )
 
@@ -16,90 +18,129 @@ type ContextKey string
 
 const (
 	GRAPHQL_CTX_NAKAMA_MODULE ContextKey = "nakama_module"
+	GRAPHQL_CTX_LOADERS       ContextKey = "loaders"
 )
 
-var (
-	userType = graphql.NewObject(graphql.ObjectConfig{
-		Name:        "User",
-		Description: "A registered Nakama user.",
-		Fields: graphql.Fields{
-			"id": &graphql.Field{
-				Type:        graphql.NewNonNull(graphql.String),
-				Description: "The id of the user.",
-				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-					return p.Source.(*api.User).GetId(), nil
-				},
-			},
-			"username": &graphql.Field{
-				Type:        graphql.String,
-				Description: "The username of the user.",
-				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-					return p.Source.(*api.User).GetUsername(), nil
-				},
-			},
-		},
-	})
-
-	fields = graphql.Fields{
-		"userByUsername": &graphql.Field{
-			Type: userType,
-			Args: graphql.FieldConfigArgument{
-				"username": &graphql.ArgumentConfig{
-					// Description: "If omitted, returns the hero of the whole saga. If " +
-					// 	"provided, returns the hero of that particular episode.",
-					Type: graphql.NewNonNull(graphql.String),
-				},
-			},
-			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-				nk := p.Context.Value(GRAPHQL_CTX_NAKAMA_MODULE).(runtime.NakamaModule)
-				usernameParam := p.Args["username"].(string)
-				users, err := nk.UsersGetUsername(p.Context, []string{usernameParam})
-				if err != nil {
-					return nil, err
-				}
-				if len(users) < 1 {
-					return nil, fmt.Errorf("no user with username `%s`", usernameParam)
-				}
-				return users[0], nil
-			},
-		},
-	}
-	rootQuery    = graphql.ObjectConfig{Name: "RootQuery", Fields: fields}
-	schemaConfig = graphql.SchemaConfig{Query: graphql.NewObject(rootQuery)}
-	schema       graphql.Schema
-)
+// schema is built by RegisterGraphQL from the base builder plus whatever
+// SchemaExtensions the caller passes in, so it's only ready for use once
+// RegisterGraphQL has returned.
+var schema graphql.Schema
+
+var graphQLRoutes = []rpc.RPCRoute{
+	&rpc.JsonRoute{ID: "graphql", NewRequest: func() interface{} { return new(GraphQLRequest) }, Handler: query},
+	subscriptionRoute,
+	introspectionRoute,
+}
 
-var (
-	graphQLRoutes = []rpc.RPCRoute{
-		&rpc.JsonRoute{"graphql", func() interface{} { return new(GraphQLRequest) }, query},
+// RegisterGraphQL builds the schema from Poseidon's built-in Nakama types
+// plus any extensions and registers the query, subscription, and schema
+// introspection RPC routes. Game-specific modules in the same binary
+// contribute their own queries, mutations, and subscriptions by passing a
+// SchemaExtension, e.g.:
+//
+//	graphql.RegisterGraphQL(init, func(b *graphql.SchemaBuilder) {
+//		b.AddQuery("inventory", inventoryField)
+//	})
+func RegisterGraphQL(init runtime.Initializer, extensions ...SchemaExtension) error {
+	builder := NewBaseSchemaBuilder()
+	for _, extend := range extensions {
+		extend(builder)
 	}
-)
 
-func RegisterGraphQL(init runtime.Initializer) error {
 	var err error
-	schema, err = graphql.NewSchema(schemaConfig)
+	schema, err = builder.Build()
 	if err != nil {
 		return err
 	}
 	return rpc.RegisterRoutes(init, graphQLRoutes)
 }
 
+// GraphQLRequest is the standard GraphQL-over-HTTP request payload
+// (https://graphql.org/learn/serving-over-http/#post-request), extended
+// with Extensions so Apollo-style persisted query hashes can ride alongside
+// the query.
 type GraphQLRequest struct {
-	Query string `json:"query"`
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    map[string]interface{} `json:"extensions"`
+}
+
+// persistedQueryHash extracts the sha256Hash a client sent under the
+// Apollo persisted query extension, if any.
+func persistedQueryHash(req *GraphQLRequest) (string, bool) {
+	ext, ok := req.Extensions["persistedQuery"].(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	hash, ok := ext["sha256Hash"].(string)
+	return hash, ok
 }
 
+func resolvePersistedQuery(req *GraphQLRequest) (string, error) {
+	hash, hasHash := persistedQueryHash(req)
+	if !hasHash {
+		return req.Query, nil
+	}
+	if req.Query == "" {
+		query, ok := persistedQueries.Get(hash)
+		if !ok {
+			return "", fmt.Errorf("PersistedQueryNotFound")
+		}
+		return query, nil
+	}
+	sum := sha256.Sum256([]byte(req.Query))
+	if hex.EncodeToString(sum[:]) != hash {
+		return "", fmt.Errorf("provided sha256Hash does not match hash of query")
+	}
+	persistedQueries.Set(hash, req.Query)
+	return req.Query, nil
+}
+
+// anonymousRateLimitKey is the bucket charged against callers with no
+// Nakama session, e.g. a server-to-server RPC made before authentication.
+// Without this, omitting RUNTIME_CTX_USER_ID would skip rate limiting
+// entirely instead of just losing its per-user granularity.
+const anonymousRateLimitKey = "~anonymous"
+
 func query(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, request interface{}) (interface{}, error) {
-	query := request.(*GraphQLRequest)
-	logger.Info("query: %+v %s", query, runtime.RUNTIME_CTX_MATCH_NODE)
+	req := request.(*GraphQLRequest)
+	logger.Info("query: %+v %s", req, runtime.RUNTIME_CTX_MATCH_NODE)
+
+	rateLimitKey := anonymousRateLimitKey
+	if userID, ok := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string); ok && userID != "" {
+		rateLimitKey = userID
+	}
+	if !rateLimiter.Allow(rateLimitKey) {
+		return errorResponse("rate limit exceeded", map[string]interface{}{"code": "RATE_LIMITED"}), nil
+	}
+
+	// Rate limiting runs before resolvePersistedQuery so a flood of distinct
+	// bogus hashes can't grow the persisted query store faster than a single
+	// caller's own budget allows.
+	requestString, err := resolvePersistedQuery(req)
+	if err != nil {
+		logger.Error("failed to resolve persisted query: %s", err.Error())
+		return errorResponse(err.Error(), map[string]interface{}{"code": "PERSISTED_QUERY_NOT_FOUND"}), nil
+	}
+
+	if _, err := checkComplexity(requestString, req.Variables); err != nil {
+		logger.Error("rejected graphql operation for exceeding complexity budget: %s", err.Error())
+		return errorResponse(err.Error(), map[string]interface{}{"code": "QUERY_TOO_COMPLEX"}), nil
+	}
+
 	newCtx := context.WithValue(ctx, GRAPHQL_CTX_NAKAMA_MODULE, nk)
+	newCtx = context.WithValue(newCtx, GRAPHQL_CTX_LOADERS, dataloader.NewLoaders(nk))
 	params := graphql.Params{
-		Schema:        schema,
-		RequestString: query.Query,
-		Context:       newCtx,
+		Schema:         schema,
+		RequestString:  requestString,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        newCtx,
 	}
 	r := graphql.Do(params)
 	if len(r.Errors) > 0 {
 		logger.Error("failed to execute graphql operation, errors: %+v", r.Errors)
 	}
-	return r, nil
+	return toResponse(r), nil
 }
@@ -0,0 +1,16 @@
+package graphql
+
+import "encoding/json"
+
+// decodeJSONObject unmarshals an arbitrary JSON object, the shape used for
+// wallet changesets, notification content, and storage object metadata.
+func decodeJSONObject(raw string) (map[string]interface{}, error) {
+	object := make(map[string]interface{})
+	if raw == "" {
+		return object, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
@@ -0,0 +1,53 @@
+package graphql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/graphql-go/graphql"
+	"github.com/heroiclabs/nakama/runtime"
+
+	"github.com/mastern2k3/poseidon/rpc"
+)
+
+// introspectionQuery is the standard GraphQL introspection document, the
+// same one tools like GraphiQL and the Apollo Playground send to discover
+// a schema's types and fields.
+const introspectionQuery = `
+	query IntrospectionQuery {
+		__schema {
+			queryType { name }
+			mutationType { name }
+			subscriptionType { name }
+			types {
+				kind
+				name
+				description
+				fields(includeDeprecated: true) {
+					name
+					description
+					args { name description type { kind name ofType { kind name } } defaultValue }
+					type { kind name ofType { kind name ofType { kind name } } }
+					isDeprecated
+					deprecationReason
+				}
+				enumValues(includeDeprecated: true) { name description isDeprecated deprecationReason }
+			}
+			directives { name description locations args { name description } }
+		}
+	}
+`
+
+// introspectionRoute exposes __schema over its own RPC id rather than the
+// regular "graphql" query route, so schema-aware tooling (GraphiQL, the
+// Apollo Playground, codegen) can fetch it with a fixed no-argument call
+// instead of constructing a GraphQLRequest themselves.
+var introspectionRoute = &rpc.JsonRoute{ID: "graphql_schema", NewRequest: func() interface{} { return new(struct{}) }, Handler: introspect}
+
+func introspect(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, request interface{}) (interface{}, error) {
+	r := graphql.Do(graphql.Params{Schema: schema, RequestString: introspectionQuery})
+	if len(r.Errors) > 0 {
+		logger.Error("failed to run schema introspection, errors: %+v", r.Errors)
+	}
+	return toResponse(r), nil
+}
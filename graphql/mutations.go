@@ -0,0 +1,193 @@
+package graphql
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/heroiclabs/nakama/runtime"
+)
+
+// mutationFields holds the top-level entry points that change Nakama state.
+var mutationFields = graphql.Fields{
+	"storageWrite": &graphql.Field{
+		Type:        graphql.String,
+		Description: "Write a storage object and return its new version.",
+		Args: graphql.FieldConfigArgument{
+			"collection":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"key":             &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"userId":          &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"value":           &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"permissionRead":  &graphql.ArgumentConfig{Type: graphql.Int},
+			"permissionWrite": &graphql.ArgumentConfig{Type: graphql.Int},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			nk := p.Context.Value(GRAPHQL_CTX_NAKAMA_MODULE).(runtime.NakamaModule)
+			permissionRead := 1
+			if v, ok := p.Args["permissionRead"].(int); ok {
+				permissionRead = v
+			}
+			permissionWrite := 1
+			if v, ok := p.Args["permissionWrite"].(int); ok {
+				permissionWrite = v
+			}
+			acks, err := nk.StorageWrite(p.Context, []*runtime.StorageWrite{
+				{
+					Collection:      p.Args["collection"].(string),
+					Key:             p.Args["key"].(string),
+					UserID:          p.Args["userId"].(string),
+					Value:           p.Args["value"].(string),
+					PermissionRead:  permissionRead,
+					PermissionWrite: permissionWrite,
+				},
+			})
+			if err != nil {
+				return nil, err
+			}
+			if len(acks) < 1 {
+				return nil, nil
+			}
+			return acks[0].GetVersion(), nil
+		},
+	},
+	"storageDelete": &graphql.Field{
+		Type:        graphql.Boolean,
+		Description: "Delete a storage object.",
+		Args: graphql.FieldConfigArgument{
+			"collection": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"key":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"userId":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			nk := p.Context.Value(GRAPHQL_CTX_NAKAMA_MODULE).(runtime.NakamaModule)
+			err := nk.StorageDelete(p.Context, []*runtime.StorageDelete{
+				{
+					Collection: p.Args["collection"].(string),
+					Key:        p.Args["key"].(string),
+					UserID:     p.Args["userId"].(string),
+				},
+			})
+			if err != nil {
+				return nil, err
+			}
+			return true, nil
+		},
+	},
+	"walletUpdate": &graphql.Field{
+		Type:        graphql.Boolean,
+		Description: "Apply a changeset to a user's wallet.",
+		Args: graphql.FieldConfigArgument{
+			"userId":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"changeset": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			nk := p.Context.Value(GRAPHQL_CTX_NAKAMA_MODULE).(runtime.NakamaModule)
+			changeset, err := decodeJSONObject(p.Args["changeset"].(string))
+			if err != nil {
+				return nil, err
+			}
+			if err := nk.WalletUpdate(p.Context, p.Args["userId"].(string), changeset, nil, true); err != nil {
+				return nil, err
+			}
+			return true, nil
+		},
+	},
+	"groupCreate": &graphql.Field{
+		Type: groupType,
+		Args: graphql.FieldConfigArgument{
+			"userId":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"name":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"description": &graphql.ArgumentConfig{Type: graphql.String},
+			"langTag":     &graphql.ArgumentConfig{Type: graphql.String},
+			"avatarUrl":   &graphql.ArgumentConfig{Type: graphql.String},
+			"open":        &graphql.ArgumentConfig{Type: graphql.Boolean},
+			"maxCount":    &graphql.ArgumentConfig{Type: graphql.Int},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			nk := p.Context.Value(GRAPHQL_CTX_NAKAMA_MODULE).(runtime.NakamaModule)
+			userID := p.Args["userId"].(string)
+			open, _ := p.Args["open"].(bool)
+			maxCount := 100
+			if v, ok := p.Args["maxCount"].(int); ok {
+				maxCount = v
+			}
+			description, _ := p.Args["description"].(string)
+			langTag, _ := p.Args["langTag"].(string)
+			avatarURL, _ := p.Args["avatarUrl"].(string)
+			return nk.GroupCreate(p.Context, userID, p.Args["name"].(string), userID, langTag, description, avatarURL, open, nil, maxCount)
+		},
+	},
+	"groupUpdate": &graphql.Field{
+		Type: graphql.Boolean,
+		Args: graphql.FieldConfigArgument{
+			"groupId":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"userId":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"name":        &graphql.ArgumentConfig{Type: graphql.String},
+			"description": &graphql.ArgumentConfig{Type: graphql.String},
+			"avatarUrl":   &graphql.ArgumentConfig{Type: graphql.String},
+			"open":        &graphql.ArgumentConfig{Type: graphql.Boolean},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			nk := p.Context.Value(GRAPHQL_CTX_NAKAMA_MODULE).(runtime.NakamaModule)
+			groupID := p.Args["groupId"].(string)
+			userID := p.Args["userId"].(string)
+			name, _ := p.Args["name"].(string)
+			description, _ := p.Args["description"].(string)
+			avatarURL, _ := p.Args["avatarUrl"].(string)
+			open, hasOpen := p.Args["open"].(bool)
+			if !hasOpen {
+				// Unlike name/description/avatarUrl, GroupUpdate always
+				// applies open rather than skipping it when empty, so a
+				// caller that only wants to rename/re-describe a group must
+				// have its current value round-tripped back in, or the
+				// group would be silently flipped closed.
+				groups, err := nk.GroupsGetId(p.Context, []string{groupID})
+				if err != nil {
+					return nil, err
+				}
+				if len(groups) < 1 {
+					return nil, fmt.Errorf("no group with id `%s`", groupID)
+				}
+				open = groups[0].GetOpen()
+			}
+			err := nk.GroupUpdate(p.Context, groupID, name, userID, "", description, avatarURL, open, nil, 0)
+			if err != nil {
+				return nil, err
+			}
+			return true, nil
+		},
+	},
+	"notificationsSend": &graphql.Field{
+		Type: graphql.Boolean,
+		Args: graphql.FieldConfigArgument{
+			"userId":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"subject":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"content":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"code":       &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			"senderId":   &graphql.ArgumentConfig{Type: graphql.String},
+			"persistent": &graphql.ArgumentConfig{Type: graphql.Boolean},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			nk := p.Context.Value(GRAPHQL_CTX_NAKAMA_MODULE).(runtime.NakamaModule)
+			content, err := decodeJSONObject(p.Args["content"].(string))
+			if err != nil {
+				return nil, err
+			}
+			persistent, _ := p.Args["persistent"].(bool)
+			senderID, _ := p.Args["senderId"].(string)
+			err = nk.NotificationSend(p.Context, p.Args["userId"].(string), p.Args["subject"].(string), content, int(p.Args["code"].(int)), senderID, persistent)
+			if err != nil {
+				return nil, err
+			}
+			return true, nil
+		},
+	},
+	// groupUsersAdd, linkCustom, and unlinkCustom were asked for in the
+	// original request, but runtime.NakamaModule has no server-invokable
+	// method for any of them: group membership changes only expose
+	// RegisterBeforeAddGroupUsers/RegisterAfterAddGroupUsers hooks around
+	// the client-driven RPC, and custom id linking is a client session
+	// operation the module interface only lets a module observe via
+	// RegisterBefore/AfterLinkCustom, not trigger directly. There's no
+	// resolver to write against this API, so the fields are left out
+	// rather than calling methods that don't exist.
+}
@@ -0,0 +1,265 @@
+// Package pbgql derives graphql-go object types from generated protobuf
+// message structs via reflection, so the GraphQL schema for a message like
+// api.Account stays in sync with Nakama's api package without a hand-written
+// resolver per field. The approach mirrors gql.BindFields in the notify.moe
+// codebase: walk the struct once, cache the result, and hand back a ready to
+// register *graphql.Object.
+package pbgql
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Timestamp is a custom scalar serializing a *timestamp.Timestamp as an RFC
+// 3339 string, so clients don't need to know about proto's seconds/nanos
+// representation.
+var Timestamp = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Timestamp",
+	Description: "An RFC 3339 encoded point in time.",
+	Serialize: func(value interface{}) interface{} {
+		ts, ok := value.(*timestamp.Timestamp)
+		if !ok || ts == nil {
+			return nil
+		}
+		return time.Unix(ts.GetSeconds(), int64(ts.GetNanos())).UTC().Format(time.RFC3339)
+	},
+	ParseValue:  func(value interface{}) interface{} { return value },
+	ParseLiteral: func(valueAST ast.Value) interface{} {
+		if lit, ok := valueAST.(*ast.StringValue); ok {
+			return lit.Value
+		}
+		return nil
+	},
+})
+
+// Duration is a custom scalar serializing a *duration.Duration as seconds.
+var Duration = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Duration",
+	Description: "A span of time expressed as fractional seconds.",
+	Serialize: func(value interface{}) interface{} {
+		d, ok := value.(*duration.Duration)
+		if !ok || d == nil {
+			return nil
+		}
+		return (time.Duration(d.GetSeconds())*time.Second + time.Duration(d.GetNanos())).Seconds()
+	},
+	ParseValue:  func(value interface{}) interface{} { return value },
+	ParseLiteral: func(valueAST ast.Value) interface{} {
+		if lit, ok := valueAST.(*ast.FloatValue); ok {
+			return lit.Value
+		}
+		return nil
+	},
+})
+
+var (
+	mu    sync.Mutex
+	cache = map[reflect.Type]*graphql.Object{}
+	enums = map[reflect.Type]*graphql.Enum{}
+)
+
+// BindMessage reflects over msg's exported fields and returns a
+// *graphql.Object describing it, building nested message, list, and enum
+// types as needed. Repeat calls for the same Go type return the cached
+// object so recursive references (e.g. Group -> GroupUser -> User) resolve
+// to the same *graphql.Object instance, which graphql-go requires.
+func BindMessage(msg interface{}) *graphql.Object {
+	mu.Lock()
+	defer mu.Unlock()
+	return bindType(reflect.TypeOf(msg))
+}
+
+func bindType(t reflect.Type) *graphql.Object {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if obj, ok := cache[t]; ok {
+		return obj
+	}
+
+	// Register a placeholder before recursing so self- or mutually-
+	// referential messages don't recurse forever.
+	fields := graphql.Fields{}
+	obj := graphql.NewObject(graphql.ObjectConfig{
+		Name:        t.Name(),
+		Description: "Generated from the " + t.Name() + " protobuf message.",
+		Fields:      fields,
+	})
+	cache[t] = obj
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+		name, ok := fieldName(sf)
+		if !ok {
+			continue
+		}
+		fieldType, resolve := bindField(sf)
+		if fieldType == nil {
+			continue
+		}
+		fields[name] = &graphql.Field{
+			Type: fieldType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				v := reflect.ValueOf(p.Source)
+				for v.Kind() == reflect.Ptr {
+					if v.IsNil() {
+						return nil, nil
+					}
+					v = v.Elem()
+				}
+				return resolve(v.FieldByName(sf.Name)), nil
+			},
+		}
+	}
+	return obj
+}
+
+// fieldName extracts the lowerCamelCase GraphQL field name from a protobuf
+// struct tag, e.g. `protobuf:"bytes,2,opt,name=display_name,json=displayName"`.
+// protoc-gen-go omits the json= component whenever it's identical to name=
+// (every single-word field, e.g. `protobuf:"bytes,1,opt,name=id"`), so fall
+// back to camelCasing name= in that case instead of dropping the field.
+func fieldName(sf reflect.StructField) (string, bool) {
+	tag := sf.Tag.Get("protobuf")
+	if tag == "" {
+		return "", false
+	}
+	name := ""
+	for _, part := range strings.Split(tag, ",") {
+		switch {
+		case strings.HasPrefix(part, "json="):
+			return strings.TrimPrefix(part, "json="), true
+		case strings.HasPrefix(part, "name="):
+			name = strings.TrimPrefix(part, "name=")
+		}
+	}
+	if name == "" {
+		return "", false
+	}
+	return snakeToLowerCamel(name), true
+}
+
+// snakeToLowerCamel converts a protobuf snake_case field name (e.g.
+// "display_name") to the lowerCamelCase form protoc-gen-go would have put
+// in json= (e.g. "displayName").
+func snakeToLowerCamel(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] == "" {
+			continue
+		}
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// bindField maps a single struct field's Go type to a GraphQL output type
+// and a function extracting the GraphQL value from the field's reflect.Value.
+func bindField(sf reflect.StructField) (graphql.Output, func(reflect.Value) interface{}) {
+	t := sf.Type
+	switch {
+	case t == reflect.TypeOf(&timestamp.Timestamp{}):
+		return Timestamp, func(v reflect.Value) interface{} { return v.Interface() }
+	case t == reflect.TypeOf(&duration.Duration{}):
+		return Duration, func(v reflect.Value) interface{} { return v.Interface() }
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		// []byte
+		return graphql.String, func(v reflect.Value) interface{} { return string(v.Bytes()) }
+	case t.Kind() == reflect.Slice:
+		elemType, elemResolve := bindElem(t.Elem())
+		if elemType == nil {
+			return nil, nil
+		}
+		return graphql.NewList(elemType), func(v reflect.Value) interface{} {
+			out := make([]interface{}, v.Len())
+			for i := range out {
+				out[i] = elemResolve(v.Index(i))
+			}
+			return out
+		}
+	case t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct:
+		return bindType(t.Elem()), func(v reflect.Value) interface{} { return v.Interface() }
+	case t.Kind() == reflect.Struct:
+		return bindType(t), func(v reflect.Value) interface{} { return v.Addr().Interface() }
+	case isProtoEnum(t):
+		return bindEnum(t), func(v reflect.Value) interface{} { return int(v.Int()) }
+	default:
+		return scalarFor(t), func(v reflect.Value) interface{} { return v.Interface() }
+	}
+}
+
+func bindElem(t reflect.Type) (graphql.Output, func(reflect.Value) interface{}) {
+	switch {
+	case t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct:
+		return bindType(t.Elem()), func(v reflect.Value) interface{} { return v.Interface() }
+	case isProtoEnum(t):
+		return bindEnum(t), func(v reflect.Value) interface{} { return int(v.Int()) }
+	default:
+		return scalarFor(t), func(v reflect.Value) interface{} { return v.Interface() }
+	}
+}
+
+// isProtoEnum reports whether t looks like a protoc-gen-go enum type: a
+// defined int32 type with a String method. The generated "<Type>_name" map
+// itself is not checked, so any int32 type with a String method matches.
+func isProtoEnum(t reflect.Type) bool {
+	if t.Kind() != reflect.Int32 {
+		return false
+	}
+	return t.Implements(reflect.TypeOf((*interface{ String() string })(nil)).Elem())
+}
+
+// bindEnum builds (and caches) a graphql.Enum from a protoc-gen-go enum's
+// names, looked up by calling String() on the values 0 through 63. A value
+// with no name makes String() return its decimal form, and such values are
+// skipped.
+func bindEnum(t reflect.Type) *graphql.Enum {
+	if enum, ok := enums[t]; ok {
+		return enum
+	}
+	values := graphql.EnumValueConfigMap{}
+	seen := map[string]bool{}
+	for i := int32(0); i < 64; i++ {
+		v := reflect.New(t).Elem()
+		v.SetInt(int64(i))
+		name := v.Interface().(interface{ String() string }).String()
+		if name == "" || name == strconv.Itoa(int(i)) || seen[name] {
+			continue
+		}
+		seen[name] = true
+		values[strings.ToUpper(name)] = &graphql.EnumValueConfig{Value: i}
+	}
+	enum := graphql.NewEnum(graphql.EnumConfig{
+		Name:   t.Name(),
+		Values: values,
+	})
+	enums[t] = enum
+	return enum
+}
+
+func scalarFor(t reflect.Type) graphql.Output {
+	switch t.Kind() {
+	case reflect.String:
+		return graphql.String
+	case reflect.Bool:
+		return graphql.Boolean
+	case reflect.Int32, reflect.Int64, reflect.Int, reflect.Uint32, reflect.Uint64:
+		return graphql.Float
+	case reflect.Float32, reflect.Float64:
+		return graphql.Float
+	default:
+		return nil
+	}
+}
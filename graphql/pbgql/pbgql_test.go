@@ -0,0 +1,50 @@
+package pbgql
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/heroiclabs/nakama/api"
+)
+
+func TestBindMessageMapsScalarFields(t *testing.T) {
+	obj := BindMessage(&api.User{})
+
+	fields := obj.Fields()
+	for _, name := range []string{"id", "username", "displayName"} {
+		if _, ok := fields[name]; !ok {
+			t.Fatalf("expected field %q on generated User type, got %v", name, fieldNames(fields))
+		}
+	}
+	if fields["id"].Type != graphql.String {
+		t.Errorf("expected User.id to be String, got %v", fields["id"].Type)
+	}
+}
+
+func TestBindMessageCachesByGoType(t *testing.T) {
+	first := BindMessage(&api.Account{})
+	second := BindMessage(&api.Account{})
+	if first != second {
+		t.Fatal("expected repeat BindMessage calls for the same Go type to return the same *graphql.Object")
+	}
+}
+
+func TestBindMessageNestsStructFields(t *testing.T) {
+	obj := BindMessage(&api.Account{})
+	fields := obj.Fields()
+	userField, ok := fields["user"]
+	if !ok {
+		t.Fatalf("expected Account to expose a nested user field, got %v", fieldNames(fields))
+	}
+	if _, ok := userField.Type.(*graphql.Object); !ok {
+		t.Errorf("expected Account.user to be bound to a generated *graphql.Object, got %T", userField.Type)
+	}
+}
+
+func fieldNames(fields graphql.FieldDefinitionMap) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,57 @@
+package graphql
+
+import "sync"
+
+// PersistedQueryStore resolves and records persisted query documents keyed
+// by their SHA-256 hash, so clients implementing the Apollo persisted
+// query protocol can send only the hash after the first request uploads
+// the full query text. The default store is in-memory and per-process;
+// callers that need a shared or durable store (e.g. across multiple Nakama
+// nodes) can implement this interface against Redis or the storage engine
+// and pass it to SetPersistedQueryStore.
+type PersistedQueryStore interface {
+	Get(hash string) (query string, ok bool)
+	Set(hash, query string)
+}
+
+// maxPersistedQueries bounds the in-memory store so that, even behind the
+// per-caller rate limit, a long enough trickle of distinct hashes from many
+// different users can't grow it without limit. Once full, new queries are
+// simply not cached; the client falls back to sending the full query text.
+const maxPersistedQueries = 10000
+
+type memoryPersistedQueryStore struct {
+	mu      sync.RWMutex
+	queries map[string]string
+}
+
+func newMemoryPersistedQueryStore() *memoryPersistedQueryStore {
+	return &memoryPersistedQueryStore{queries: make(map[string]string)}
+}
+
+func (s *memoryPersistedQueryStore) Get(hash string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	query, ok := s.queries[hash]
+	return query, ok
+}
+
+func (s *memoryPersistedQueryStore) Set(hash, query string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.queries[hash]; !exists && len(s.queries) >= maxPersistedQueries {
+		return
+	}
+	s.queries[hash] = query
+}
+
+// persistedQueries is the store used by query(). It defaults to an
+// in-memory map; SetPersistedQueryStore lets a game server swap in a
+// shared backend before RegisterGraphQL is called.
+var persistedQueries PersistedQueryStore = newMemoryPersistedQueryStore()
+
+// SetPersistedQueryStore overrides the store used to resolve and record
+// Apollo-style persisted queries.
+func SetPersistedQueryStore(store PersistedQueryStore) {
+	persistedQueries = store
+}
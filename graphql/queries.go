@@ -0,0 +1,142 @@
+package graphql
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/heroiclabs/nakama/api"
+	"github.com/heroiclabs/nakama/runtime"
+
+	"github.com/mastern2k3/poseidon/graphql/dataloader"
+)
+
+// queryFields holds the top-level read-only entry points into the schema.
+var queryFields = graphql.Fields{
+	"userByUsername": &graphql.Field{
+		Type: userType,
+		Args: graphql.FieldConfigArgument{
+			"username": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			loaders := p.Context.Value(GRAPHQL_CTX_LOADERS).(*dataloader.Loaders)
+			usernameParam := p.Args["username"].(string)
+			user, err := loaders.UserByUsername.Load(p.Context, usernameParam)
+			if err != nil {
+				return nil, err
+			}
+			if user == nil {
+				return nil, fmt.Errorf("no user with username `%s`", usernameParam)
+			}
+			return user.(*api.User), nil
+		},
+	},
+	"account": &graphql.Field{
+		Type:        accountType,
+		Description: "Fetch the full account, including wallet and email, for the caller's own user id.",
+		Args: graphql.FieldConfigArgument{
+			"userId": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			nk := p.Context.Value(GRAPHQL_CTX_NAKAMA_MODULE).(runtime.NakamaModule)
+			userID := p.Args["userId"].(string)
+			callerID, _ := p.Context.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+			if callerID == "" || callerID != userID {
+				return nil, fmt.Errorf("account can only be queried for the caller's own user id")
+			}
+			return nk.AccountGetId(p.Context, userID)
+		},
+	},
+	"group": &graphql.Field{
+		Type: groupType,
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			loaders := p.Context.Value(GRAPHQL_CTX_LOADERS).(*dataloader.Loaders)
+			id := p.Args["id"].(string)
+			group, err := loaders.GroupByID.Load(p.Context, id)
+			if err != nil {
+				return nil, err
+			}
+			if group == nil {
+				return nil, fmt.Errorf("no group with id `%s`", id)
+			}
+			return group.(*api.Group), nil
+		},
+	},
+	"storageObject": &graphql.Field{
+		Type: storageObjectType,
+		Args: graphql.FieldConfigArgument{
+			"collection": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"key":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"userId":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			loaders := p.Context.Value(GRAPHQL_CTX_LOADERS).(*dataloader.Loaders)
+			key := dataloader.StorageKey(p.Args["collection"].(string), p.Args["key"].(string), p.Args["userId"].(string))
+			object, err := loaders.StorageObject.Load(p.Context, key)
+			if err != nil {
+				return nil, err
+			}
+			if object == nil {
+				return nil, nil
+			}
+			return object.(*api.StorageObject), nil
+		},
+	},
+	"leaderboardRecords": &graphql.Field{
+		Type: graphql.NewList(leaderboardRecordType),
+		Args: graphql.FieldConfigArgument{
+			"leaderboardId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"ownerIds":      &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String)},
+			"limit":         &graphql.ArgumentConfig{Type: graphql.Int},
+			"cursor":        &graphql.ArgumentConfig{Type: graphql.String},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			nk := p.Context.Value(GRAPHQL_CTX_NAKAMA_MODULE).(runtime.NakamaModule)
+			leaderboardID := p.Args["leaderboardId"].(string)
+			limit := 10
+			if l, ok := p.Args["limit"].(int); ok {
+				limit = l
+			}
+			cursor, _ := p.Args["cursor"].(string)
+			var ownerIDs []string
+			if raw, ok := p.Args["ownerIds"].([]interface{}); ok {
+				for _, o := range raw {
+					ownerIDs = append(ownerIDs, o.(string))
+				}
+			}
+			records, _, _, _, err := nk.LeaderboardRecordsList(p.Context, leaderboardID, ownerIDs, limit, cursor, 0)
+			if err != nil {
+				return nil, err
+			}
+			return records, nil
+		},
+	},
+	"matches": &graphql.Field{
+		Type: graphql.NewList(matchType),
+		Args: graphql.FieldConfigArgument{
+			"limit":         &graphql.ArgumentConfig{Type: graphql.Int},
+			"authoritative": &graphql.ArgumentConfig{Type: graphql.Boolean},
+			"label":         &graphql.ArgumentConfig{Type: graphql.String},
+			"query":         &graphql.ArgumentConfig{Type: graphql.String},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			nk := p.Context.Value(GRAPHQL_CTX_NAKAMA_MODULE).(runtime.NakamaModule)
+			limit := 10
+			if l, ok := p.Args["limit"].(int); ok {
+				limit = l
+			}
+			authoritative, _ := p.Args["authoritative"].(bool)
+			label, _ := p.Args["label"].(string)
+			query, _ := p.Args["query"].(string)
+			return nk.MatchList(p.Context, limit, authoritative, label, 0, 0, query)
+		},
+	},
+}
@@ -0,0 +1,85 @@
+package graphql
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket is a minimal token-bucket limiter: it refills at rate tokens
+// per second up to burst, and Allow consumes one token per call.
+type tokenBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	rate   float64
+	burst  float64
+}
+
+func newTokenBucket(rate, burst float64) *tokenBucket {
+	return &tokenBucket{tokens: burst, last: time.Now(), rate: rate, burst: burst}
+}
+
+func (b *tokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	b.tokens += elapsed * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// RateLimit configures the per-user token bucket applied to every
+// incoming GraphQL operation.
+type RateLimit struct {
+	// RequestsPerSecond is the sustained rate a single Nakama user id may
+	// issue GraphQL operations at.
+	RequestsPerSecond float64
+	// Burst is the largest number of operations a user may issue
+	// instantaneously before RequestsPerSecond starts throttling them.
+	Burst float64
+}
+
+// DefaultRateLimit is applied to every user unless overridden with
+// SetRateLimit before RegisterGraphQL.
+var DefaultRateLimit = RateLimit{RequestsPerSecond: 5, Burst: 10}
+
+var rateLimit = DefaultRateLimit
+
+// SetRateLimit overrides the per-user rate limit enforced before graphql.Do
+// runs.
+func SetRateLimit(r RateLimit) {
+	rateLimit = r
+}
+
+type userRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newUserRateLimiter() *userRateLimiter {
+	return &userRateLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+// Allow reports whether userID may issue another operation right now,
+// lazily creating its bucket on first use.
+func (l *userRateLimiter) Allow(userID string) bool {
+	l.mu.Lock()
+	bucket, ok := l.buckets[userID]
+	if !ok {
+		bucket = newTokenBucket(rateLimit.RequestsPerSecond, rateLimit.Burst)
+		l.buckets[userID] = bucket
+	}
+	l.mu.Unlock()
+	return bucket.Allow()
+}
+
+var rateLimiter = newUserRateLimiter()
@@ -0,0 +1,57 @@
+package graphql
+
+import (
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+// Response is the canonical GraphQL-over-HTTP response envelope
+// (https://spec.graphql.org/#sec-Response), so standard clients such as
+// Apollo, urql, or Relay can talk to the Nakama RPC transport without an
+// adapter translating graphql-go's internal *graphql.Result shape.
+type Response struct {
+	Data   interface{}     `json:"data,omitempty"`
+	Errors []ResponseError `json:"errors,omitempty"`
+}
+
+// ResponseError is a single entry of Response.Errors.
+type ResponseError struct {
+	Message    string                 `json:"message"`
+	Locations  []ResponseLocation     `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// ResponseLocation is the line/column of an error within the request
+// document.
+type ResponseLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// toResponse converts a graphql-go result into the canonical envelope.
+func toResponse(r *graphql.Result) *Response {
+	resp := &Response{Data: r.Data}
+	for _, e := range r.Errors {
+		resp.Errors = append(resp.Errors, toResponseError(e))
+	}
+	return resp
+}
+
+func toResponseError(e gqlerrors.FormattedError) ResponseError {
+	re := ResponseError{
+		Message: e.Message,
+		Path:    e.Path,
+	}
+	for _, loc := range e.Locations {
+		re.Locations = append(re.Locations, ResponseLocation{Line: loc.Line, Column: loc.Column})
+	}
+	return re
+}
+
+// errorResponse builds a Response carrying a single top-level error, for
+// failures (e.g. an unresolved persisted query hash) that happen before
+// graphql.Do ever runs.
+func errorResponse(message string, extensions map[string]interface{}) *Response {
+	return &Response{Errors: []ResponseError{{Message: message, Extensions: extensions}}}
+}
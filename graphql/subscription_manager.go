@@ -0,0 +1,209 @@
+package graphql
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/heroiclabs/nakama/runtime"
+
+	"github.com/mastern2k3/poseidon/graphql/dataloader"
+	"github.com/mastern2k3/poseidon/rpc"
+)
+
+// SubscriptionMessage mirrors the graphql-transport-ws sub-protocol
+// (https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md)
+// message shape. Because the Nakama RPC transport is request/response
+// rather than a persistent socket, a client drives a subscription by
+// repeatedly sending "next" messages to long-poll for the next event
+// instead of having one pushed down an open connection.
+type SubscriptionMessage struct {
+	Type         string          `json:"type"`
+	Id           string          `json:"id,omitempty"`
+	ConnectionId string          `json:"connectionId,omitempty"`
+	Payload      *GraphQLRequest `json:"payload,omitempty"`
+}
+
+// subscriptionMessageResponse is what the RPC handler returns; Payload here
+// carries a *Response rather than a request once a subscription produces a
+// result.
+type subscriptionMessageResponse struct {
+	Type         string    `json:"type"`
+	Id           string    `json:"id,omitempty"`
+	ConnectionId string    `json:"connectionId,omitempty"`
+	Payload      *Response `json:"payload,omitempty"`
+}
+
+// longPollTimeout bounds how long a single "next" call blocks waiting for
+// an event before returning an empty keep-alive "next" message.
+const longPollTimeout = 25 * time.Second
+
+// subscriptionIdleTimeout bounds how long a subscription may go without a
+// "next" poll before the reaper tears it down. A client that stops polling
+// without ever sending "complete" (crash, dropped connection, buggy retry
+// loop) would otherwise leak its graphql.Subscribe goroutine, the broker
+// channel it's reading from, and its connections map entry forever.
+const subscriptionIdleTimeout = 2 * longPollTimeout
+
+// reapInterval is how often the reaper sweeps for idle subscriptions.
+const reapInterval = longPollTimeout
+
+type subscription struct {
+	results  <-chan *graphql.Result
+	cancel   context.CancelFunc
+	lastPoll time.Time
+}
+
+type subscriptionManager struct {
+	mu          sync.Mutex
+	connections map[string]map[string]*subscription
+}
+
+func newSubscriptionManager() *subscriptionManager {
+	m := &subscriptionManager{connections: make(map[string]map[string]*subscription)}
+	go m.reapLoop()
+	return m
+}
+
+// reapLoop periodically removes subscriptions that haven't been polled
+// within subscriptionIdleTimeout, so an abandoned client can't leak a
+// subscription for the lifetime of the process.
+func (m *subscriptionManager) reapLoop() {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		m.reapIdle(time.Now())
+	}
+}
+
+func (m *subscriptionManager) reapIdle(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for connectionID, subs := range m.connections {
+		for id, sub := range subs {
+			if now.Sub(sub.lastPoll) > subscriptionIdleTimeout {
+				sub.cancel()
+				delete(subs, id)
+			}
+		}
+		if len(subs) == 0 {
+			delete(m.connections, connectionID)
+		}
+	}
+}
+
+func (m *subscriptionManager) open(connectionID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connections[connectionID] == nil {
+		m.connections[connectionID] = make(map[string]*subscription)
+	}
+}
+
+func (m *subscriptionManager) add(connectionID, id string, sub *subscription) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sub.lastPoll = time.Now()
+	if m.connections[connectionID] == nil {
+		m.connections[connectionID] = make(map[string]*subscription)
+	}
+	m.connections[connectionID][id] = sub
+}
+
+func (m *subscriptionManager) get(connectionID, id string) (*subscription, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sub, ok := m.connections[connectionID][id]
+	if ok {
+		sub.lastPoll = time.Now()
+	}
+	return sub, ok
+}
+
+func (m *subscriptionManager) remove(connectionID, id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if sub, ok := m.connections[connectionID][id]; ok {
+		sub.cancel()
+		delete(m.connections[connectionID], id)
+	}
+}
+
+var subscriptions = newSubscriptionManager()
+
+// nextConnectionID returns an unguessable token rather than a sequential id,
+// since it's trusted verbatim from the client on every subsequent
+// subscribe/next/complete call - a predictable id would let one client read
+// or tear down another's subscriptions just by enumerating small integers.
+func nextConnectionID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+var subscriptionRoute = &rpc.JsonRoute{ID: "graphql_subscription", NewRequest: func() interface{} { return new(SubscriptionMessage) }, Handler: subscriptionQuery}
+
+func subscriptionQuery(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, request interface{}) (interface{}, error) {
+	msg := request.(*SubscriptionMessage)
+
+	switch msg.Type {
+	case "connection_init":
+		connectionID := nextConnectionID()
+		subscriptions.open(connectionID)
+		return &subscriptionMessageResponse{Type: "connection_ack", ConnectionId: connectionID}, nil
+
+	case "subscribe":
+		if msg.Payload == nil {
+			return nil, fmt.Errorf("subscribe message missing payload")
+		}
+		// The subscription outlives this single RPC call, so it gets its own
+		// cancellable context rather than inheriting ctx's lifetime - but it
+		// still needs ctx's RUNTIME_CTX_USER_ID so per-user authorization
+		// checks in subscriptionFields resolvers (see subscriptions.go) see
+		// who's actually subscribing.
+		subCtx, cancel := context.WithCancel(context.Background())
+		subCtx = context.WithValue(subCtx, runtime.RUNTIME_CTX_USER_ID, ctx.Value(runtime.RUNTIME_CTX_USER_ID))
+		subCtx = context.WithValue(subCtx, GRAPHQL_CTX_NAKAMA_MODULE, nk)
+		subCtx = context.WithValue(subCtx, GRAPHQL_CTX_LOADERS, dataloader.NewLoaders(nk))
+
+		results := graphql.Subscribe(graphql.Params{
+			Schema:         schema,
+			RequestString:  msg.Payload.Query,
+			OperationName:  msg.Payload.OperationName,
+			VariableValues: msg.Payload.Variables,
+			Context:        subCtx,
+		})
+		subscriptions.add(msg.ConnectionId, msg.Id, &subscription{results: results, cancel: cancel})
+		return &subscriptionMessageResponse{Type: "subscribed", Id: msg.Id, ConnectionId: msg.ConnectionId}, nil
+
+	case "next":
+		sub, ok := subscriptions.get(msg.ConnectionId, msg.Id)
+		if !ok {
+			return nil, fmt.Errorf("no subscription %q on connection %q", msg.Id, msg.ConnectionId)
+		}
+		select {
+		case result, open := <-sub.results:
+			if !open {
+				subscriptions.remove(msg.ConnectionId, msg.Id)
+				return &subscriptionMessageResponse{Type: "complete", Id: msg.Id}, nil
+			}
+			return &subscriptionMessageResponse{Type: "next", Id: msg.Id, Payload: toResponse(result)}, nil
+		case <-time.After(longPollTimeout):
+			return &subscriptionMessageResponse{Type: "next", Id: msg.Id}, nil
+		}
+
+	case "complete":
+		subscriptions.remove(msg.ConnectionId, msg.Id)
+		return &subscriptionMessageResponse{Type: "complete", Id: msg.Id}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown graphql-transport-ws message type %q", msg.Type)
+	}
+}
@@ -0,0 +1,124 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/heroiclabs/nakama/runtime"
+)
+
+// callerUserID reads the authenticated caller's id out of the subscription
+// context, the same RUNTIME_CTX_USER_ID check queries.go's account field
+// uses, so an anonymous or server-to-server call can't open a subscription
+// on someone else's behalf.
+func callerUserID(ctx context.Context) (string, bool) {
+	callerID, ok := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	return callerID, ok && callerID != ""
+}
+
+// subscriptionFields holds the Subscription root's entry points. Each
+// Subscribe func registers with the in-memory broker (see broker.go) and
+// returns a channel of raw events; Resolve then shapes whatever the
+// channel produced for the field's declared Type, the two-step split
+// graphql-go's subscription support expects.
+var subscriptionFields = graphql.Fields{
+	"matchState": &graphql.Field{
+		Type:        matchType,
+		Description: "Streams state snapshots for a running match.",
+		Args: graphql.FieldConfigArgument{
+			"matchId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+		Subscribe: func(p graphql.ResolveParams) (interface{}, error) {
+			if _, ok := callerUserID(p.Context); !ok {
+				return nil, fmt.Errorf("matchState requires an authenticated caller")
+			}
+			matchID := p.Args["matchId"].(string)
+			ch, cancel := eventBroker.Subscribe(matchStateTopic(matchID))
+			go closeOnDone(p.Context, cancel)
+			return ch, nil
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source, nil
+		},
+	},
+	"notifications": &graphql.Field{
+		Type:        notificationType,
+		Description: "Streams notifications as they are delivered to a user.",
+		Args: graphql.FieldConfigArgument{
+			"userId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+		Subscribe: func(p graphql.ResolveParams) (interface{}, error) {
+			userID := p.Args["userId"].(string)
+			callerID, ok := callerUserID(p.Context)
+			if !ok || callerID != userID {
+				return nil, fmt.Errorf("notifications can only be streamed for the caller's own user id")
+			}
+			ch, cancel := eventBroker.Subscribe(notificationsTopic(userID))
+			go closeOnDone(p.Context, cancel)
+			return ch, nil
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source, nil
+		},
+	},
+	"channelMessages": &graphql.Field{
+		Type:        graphql.String,
+		Description: "Streams new messages posted to a chat channel, as JSON.",
+		Args: graphql.FieldConfigArgument{
+			"channelId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+		Subscribe: func(p graphql.ResolveParams) (interface{}, error) {
+			if _, ok := callerUserID(p.Context); !ok {
+				return nil, fmt.Errorf("channelMessages requires an authenticated caller")
+			}
+			channelID := p.Args["channelId"].(string)
+			ch, cancel := eventBroker.Subscribe(channelMessagesTopic(channelID))
+			go closeOnDone(p.Context, cancel)
+			return ch, nil
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return jsonEvent(p.Source)
+		},
+	},
+	"presenceEvents": &graphql.Field{
+		Type:        graphql.String,
+		Description: "Streams join/leave presence events for a stream, as JSON.",
+		Args: graphql.FieldConfigArgument{
+			"stream": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+		Subscribe: func(p graphql.ResolveParams) (interface{}, error) {
+			if _, ok := callerUserID(p.Context); !ok {
+				return nil, fmt.Errorf("presenceEvents requires an authenticated caller")
+			}
+			stream := p.Args["stream"].(string)
+			ch, cancel := eventBroker.Subscribe(presenceEventsTopic(stream))
+			go closeOnDone(p.Context, cancel)
+			return ch, nil
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return jsonEvent(p.Source)
+		},
+	},
+}
+
+// jsonEvent renders a published event as a JSON string for the String-typed
+// subscription fields; an event published as a string is passed through.
+func jsonEvent(event interface{}) (interface{}, error) {
+	if s, ok := event.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// closeOnDone releases a broker subscription once the originating request
+// context is cancelled, e.g. when a subscribe/complete pair closes it out.
+func closeOnDone(ctx context.Context, cancel func()) {
+	<-ctx.Done()
+	cancel()
+}
@@ -0,0 +1,53 @@
+package graphql
+
+import (
+	"github.com/graphql-go/graphql"
+	"github.com/heroiclabs/nakama/api"
+	"github.com/heroiclabs/nakama/runtime"
+
+	"github.com/mastern2k3/poseidon/graphql/pbgql"
+)
+
+// userType, accountType, groupUserType, storageObjectType,
+// leaderboardRecordType, notificationType, and matchType are all derived
+// straight from their Nakama api.* message via pbgql.BindMessage rather
+// than hand-written field by field, so the schema tracks the api package
+// as it evolves. groupType needs one field, "members", that isn't a plain
+// message field but a separate GroupUsersList RPC, so it's grafted onto
+// the generated object with AddFieldConfig.
+var (
+	userType              = pbgql.BindMessage(&api.User{})
+	accountType           = pbgql.BindMessage(&api.Account{})
+	groupType             = pbgql.BindMessage(&api.Group{})
+	groupUserType         = pbgql.BindMessage(&api.GroupUserList_GroupUser{})
+	storageObjectType     = pbgql.BindMessage(&api.StorageObject{})
+	leaderboardRecordType = pbgql.BindMessage(&api.LeaderboardRecord{})
+	notificationType      = pbgql.BindMessage(&api.Notification{})
+	matchType             = pbgql.BindMessage(&api.Match{})
+)
+
+func init() {
+	groupType.AddFieldConfig("members", &graphql.Field{
+		Type:        graphql.NewList(groupUserType),
+		Description: "The members of the group.",
+		Args: graphql.FieldConfigArgument{
+			"limit": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Truncates the result client-side; GroupUsersList has no server-side limit/cursor in this API version."},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			nk := p.Context.Value(GRAPHQL_CTX_NAKAMA_MODULE).(runtime.NakamaModule)
+			group := p.Source.(*api.Group)
+			limit := 100
+			if l, ok := p.Args["limit"].(int); ok {
+				limit = l
+			}
+			members, err := nk.GroupUsersList(p.Context, group.GetId())
+			if err != nil {
+				return nil, err
+			}
+			if len(members) > limit {
+				members = members[:limit]
+			}
+			return members, nil
+		},
+	})
+}